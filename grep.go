@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// grepState holds the -grep content filtering configuration shared read-only across
+// buildOutput's worker pool. The -max-results cap itself is enforced by buildOutput's
+// collector, not here, since only the collector sees files in their original order.
+type grepState struct {
+	re         *regexp.Regexp
+	context    int
+	maxResults int
+}
+
+// matchLines splits content into lines and reports which ones should be rendered:
+// every line matching g.re, plus g.context lines of surrounding context per match,
+// mirroring `grep -C`. ok is false if no line matches at all.
+func (g *grepState) matchLines(content []byte) (lines []string, include []bool, ok bool) {
+	lines = strings.Split(string(content), "\n")
+	include = make([]bool, len(lines))
+	for i, line := range lines {
+		if !g.re.MatchString(line) {
+			continue
+		}
+		ok = true
+		start := i - g.context
+		if start < 0 {
+			start = 0
+		}
+		end := i + g.context
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		for j := start; j <= end; j++ {
+			include[j] = true
+		}
+	}
+	return lines, include, ok
+}
+
+// renderGrepMatch writes a file's framing followed by only its included lines, each
+// prefixed with its original line number when opts.IncludeLineNumbers is set. A "--"
+// separator marks gaps between non-adjacent blocks of included lines, as with `grep -C`.
+func renderGrepMatch(buffer *bytes.Buffer, name string, lines []string, include []bool, opts Options) {
+	buffer.WriteString(fmt.Sprintf("{{File: %s}}\n", name))
+	prevIncluded := -2
+	for i, line := range lines {
+		if !include[i] {
+			continue
+		}
+		if prevIncluded != -2 && i != prevIncluded+1 {
+			buffer.WriteString("--\n")
+		}
+		if opts.IncludeLineNumbers {
+			buffer.WriteString(fmt.Sprintf("%d: %s\n", i+1, line))
+		} else {
+			buffer.WriteString(line)
+			buffer.WriteString("\n")
+		}
+		prevIncluded = i
+	}
+	buffer.WriteString("\n")
+}
@@ -1,16 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode/utf8"
 
 	"github.com/charmbracelet/huh"
@@ -21,6 +24,15 @@ import (
 
 // Options holds the configuration settings used for file concatenation operations.
 type Options struct {
+	SourceType         string
+	DirPath            string
+	ArchivePath        string
+	RepoURL            string
+	Jobs               int
+	GrepPattern        string
+	GrepContext        int
+	MaxResults         int
+	Watch              bool
 	CopyToClipboard    bool
 	Extensions         string
 	IncludeLineNumbers bool
@@ -35,31 +47,79 @@ type ExtInfo struct {
 
 func main() {
 	opts := parseOptions()
-	files, err := getTrackedFiles()
+
+	source, err := buildSource(opts)
+	if err != nil {
+		log.Fatal("Failed to initialize source", "error", err)
+	}
+	paths, fsys, cleanup, err := source.List()
 	if err != nil {
-		log.Fatal("Failed to list Git files", "error", err)
+		log.Fatal("Failed to list source files", "error", err)
 	}
-	output, fileCount, totalTokens := buildOutput(files, opts)
+	defer cleanup()
+
+	output, fileCount, totalTokens := buildOutput(paths, fsys, opts)
 	if err := writeOutput(output, opts); err != nil {
 		log.Error("Error writing output", "error", err)
 	}
 	log.Info("Processed files", "count", fileCount, "tokens", humanize.Comma(totalTokens))
+
+	if opts.Watch {
+		if err := watch(source, opts, sourceRoot(opts)); err != nil {
+			log.Fatal("Watch mode failed", "error", err)
+		}
+	}
+}
+
+// sourceRoot returns the on-disk directory that paths returned by Source.List are
+// relative to, for the sources -watch can meaningfully monitor (git and dir).
+func sourceRoot(opts Options) string {
+	if opts.SourceType == "dir" && opts.DirPath != "" {
+		return opts.DirPath
+	}
+	return "."
+}
+
+// buildSource constructs the Source selected by opts.SourceType. An empty SourceType
+// defaults to GitSource, unless an archive path was given, for backwards compatibility
+// with -archive used on its own.
+func buildSource(opts Options) (Source, error) {
+	switch opts.SourceType {
+	case "", "git":
+		if opts.ArchivePath != "" {
+			return ArchiveSource{Path: opts.ArchivePath}, nil
+		}
+		return GitSource{}, nil
+	case "dir":
+		return DirSource{Root: opts.DirPath}, nil
+	case "archive":
+		if opts.ArchivePath == "" {
+			return nil, fmt.Errorf("-source archive requires -archive <path>")
+		}
+		return ArchiveSource{Path: opts.ArchivePath}, nil
+	case "http":
+		if opts.RepoURL == "" {
+			return nil, fmt.Errorf("-source http requires -url <repository>")
+		}
+		return HTTPGitSource{URL: opts.RepoURL}, nil
+	default:
+		return nil, fmt.Errorf("unknown source: %s", opts.SourceType)
+	}
 }
 
 // estimateTokens estimates the number of tokens in a file based on its size.
-func estimateTokens(filename string) int64 {
-	fileInfo, err := os.Stat(filename)
+func estimateTokens(fsys fs.FS, name string) int64 {
+	info, err := fs.Stat(fsys, name)
 	if err != nil {
 		return 0
 	}
-	byteSize := fileInfo.Size()
+	byteSize := info.Size()
 	return byteSize * 10 / 35 // divide by 3.5 to estimate tokens for code
 }
 
 // isTextFile checks if a file is likely a text file by sampling its initial bytes.
-func isTextFile(filename string) bool {
-	// Open the file
-	file, err := os.Open(filename)
+func isTextFile(fsys fs.FS, name string) bool {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return false // If we can't open it, assume it's not text to skip it
 	}
@@ -71,13 +131,15 @@ func isTextFile(filename string) bool {
 	if err != nil && err != io.EOF {
 		return false
 	}
-	if n == 0 {
+	return isTextSample(buf[:n])
+}
+
+// isTextSample checks if a byte sample looks like text rather than binary data.
+func isTextSample(buf []byte) bool {
+	if len(buf) == 0 {
 		return true // Empty files can be considered text
 	}
 
-	// Trim the buffer to the actual bytes read
-	buf = buf[:n]
-
 	// Check if the content is valid UTF-8 and mostly printable
 	if !utf8.Valid(buf) {
 		return false // Invalid UTF-8 suggests binary data
@@ -92,7 +154,7 @@ func isTextFile(filename string) bool {
 	}
 
 	// If more than 10% of the sample is non-printable, assume it's binary
-	return float64(nonPrintable)/float64(n) < 0.1
+	return float64(nonPrintable)/float64(len(buf)) < 0.1
 }
 
 // checks if any component of the path starts with a dot, indicating a hidden file or directory.
@@ -122,6 +184,15 @@ func toClipboard(s string) {
 // parseOptions parses command-line flags or runs an interactive prompt.
 func parseOptions() Options {
 	// Define command-line flags
+	sourceType := flag.String("source", "", "File source to use: git (default), dir, archive, or http")
+	dirPath := flag.String("dir", "", "Directory to scan when -source dir is used (default: current directory)")
+	archivePath := flag.String("archive", "", "Path to an archive (.zip, .tar, .tar.gz, .tar.bz2) to use as the source")
+	repoURL := flag.String("url", "", "Remote Git repository URL to shallow-clone when -source http is used")
+	jobs := flag.Int("jobs", runtime.NumCPU(), "Number of parallel workers used to read and classify files")
+	grepPattern := flag.String("grep", "", "Only include files whose contents match this regular expression")
+	grepContext := flag.Int("context", 0, "Number of surrounding lines to include around each -grep match")
+	maxResults := flag.Int("max-results", 0, "Stop after this many files have matched -grep (0 means no limit)")
+	watchMode := flag.Bool("watch", false, "Keep running and regenerate the output whenever tracked files change")
 	copyToClipboard := flag.Bool("c", false, "Copy the concatenated output to the clipboard")
 	extensions := flag.String("ext", "", "Comma-separated list of file extensions to include (without leading dot)")
 	includeLineNumbers := flag.Bool("n", false, "Include line numbers in the output")
@@ -132,20 +203,76 @@ func parseOptions() Options {
 
 	// Handle interactive mode
 	if *interactive {
-		// Get list of tracked files (e.g., from Git)
-		files, err := getTrackedFiles()
+		// Prompt for the source type if one wasn't given on the command line.
+		if *sourceType == "" {
+			if err := huh.NewSelect[string]().
+				Title("Select file source:").
+				Options(
+					huh.NewOption("Git (files tracked in this repository)", "git"),
+					huh.NewOption("Directory (walk a path on disk)", "dir"),
+					huh.NewOption("Archive (zip/tar/tar.gz/tar.bz2)", "archive"),
+					huh.NewOption("Remote Git repository (shallow clone)", "http"),
+				).
+				Value(sourceType).
+				Run(); err != nil {
+				log.Fatal("Interactive selection failed", "error", err)
+			}
+		}
+
+		// Prompt for whichever source-specific parameter the chosen source needs.
+		switch *sourceType {
+		case "dir":
+			if *dirPath == "" {
+				if err := huh.NewInput().
+					Title("Directory to scan (default: current directory):").
+					Value(dirPath).
+					Run(); err != nil {
+					log.Fatal("Interactive input failed", "error", err)
+				}
+			}
+		case "archive":
+			if *archivePath == "" {
+				if err := huh.NewInput().
+					Title("Path to archive file:").
+					Value(archivePath).
+					Run(); err != nil {
+					log.Fatal("Interactive input failed", "error", err)
+				}
+			}
+		case "http":
+			if *repoURL == "" {
+				if err := huh.NewInput().
+					Title("Repository URL to clone:").
+					Value(repoURL).
+					Run(); err != nil {
+					log.Fatal("Interactive input failed", "error", err)
+				}
+			}
+		}
+
+		source, err := buildSource(Options{
+			SourceType:  *sourceType,
+			DirPath:     *dirPath,
+			ArchivePath: *archivePath,
+			RepoURL:     *repoURL,
+		})
 		if err != nil {
-			log.Fatal("Failed to list Git files", "error", err)
+			log.Fatal("Failed to initialize source", "error", err)
 		}
+		paths, fsys, cleanup, err := source.List()
+		if err != nil {
+			log.Fatal("Failed to list source files", "error", err)
+		}
+		defer cleanup()
 
 		// Build a map of extension info (file count and token estimate)
 		extInfoMap := make(map[string]ExtInfo)
-		for _, file := range files {
-			if isHiddenFile(file) || !isTextFile(file) {
+		for _, path := range paths {
+			if isHiddenFile(path) || !isTextFile(fsys, path) {
 				continue
 			}
-			ext := filepath.Ext(file)
-			tokens := estimateTokens(file)
+			ext := filepath.Ext(path)
+			tokens := estimateTokens(fsys, path)
 			info, ok := extInfoMap[ext]
 			if !ok {
 				info = ExtInfo{FileCount: 0, TotalTokens: 0}
@@ -202,6 +329,37 @@ func parseOptions() Options {
 			*extensions = ","
 		}
 
+		// Run interactive input for grep filtering
+		if err := huh.NewInput().
+			Title("Grep pattern to filter file contents by (leave empty to include all):").
+			Value(grepPattern).
+			Run(); err != nil {
+			log.Fatal("Interactive input failed", "error", err)
+		}
+		if strings.TrimSpace(*grepPattern) != "" {
+			var contextStr string
+			if err := huh.NewInput().
+				Title("Lines of context to include around each match (default: 0):").
+				Value(&contextStr).
+				Run(); err != nil {
+				log.Fatal("Interactive input failed", "error", err)
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(contextStr)); err == nil {
+				*grepContext = n
+			}
+
+			var maxResultsStr string
+			if err := huh.NewInput().
+				Title("Maximum number of matching files to include (leave empty for no limit):").
+				Value(&maxResultsStr).
+				Run(); err != nil {
+				log.Fatal("Interactive input failed", "error", err)
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(maxResultsStr)); err == nil {
+				*maxResults = n
+			}
+		}
+
 		// Run interactive confirm for line numbers
 		var includeLn bool
 		if err := huh.NewConfirm().
@@ -212,6 +370,16 @@ func parseOptions() Options {
 		}
 		*includeLineNumbers = includeLn
 
+		// Run interactive confirm for watch mode
+		var watchConfirm bool
+		if err := huh.NewConfirm().
+			Title("Keep watching for changes and regenerate the output?").
+			Value(&watchConfirm).
+			Run(); err != nil {
+			log.Fatal("Interactive confirm failed", "error", err)
+		}
+		*watchMode = watchConfirm
+
 		// Run interactive confirm for clipboard option
 		var copyClip bool
 		if err := huh.NewConfirm().
@@ -238,6 +406,15 @@ func parseOptions() Options {
 
 	// Return the parsed options
 	return Options{
+		SourceType:         *sourceType,
+		DirPath:            *dirPath,
+		ArchivePath:        *archivePath,
+		RepoURL:            *repoURL,
+		Jobs:               *jobs,
+		GrepPattern:        *grepPattern,
+		GrepContext:        *grepContext,
+		MaxResults:         *maxResults,
+		Watch:              *watchMode,
 		CopyToClipboard:    *copyToClipboard,
 		Extensions:         *extensions,
 		IncludeLineNumbers: *includeLineNumbers,
@@ -245,76 +422,190 @@ func parseOptions() Options {
 	}
 }
 
-// getTrackedFiles retrieves the list of files currently tracked by Git.
-func getTrackedFiles() ([]string, error) {
-	cmd := exec.Command("git", "ls-files", "--cached")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-	var files []string
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-	for scanner.Scan() {
-		file := scanner.Text()
-		if file != "" {
-			files = append(files, file)
+// fileResult is what a worker in buildOutput produces for a single file, tagged with
+// its original index so the collector can write results back out in order. grepMatched
+// is set when this file matched -grep, so the collector can enforce -max-results in
+// path order rather than worker-completion order.
+type fileResult struct {
+	index       int
+	skip        bool
+	content     []byte
+	tokens      int64
+	grepMatched bool
+}
+
+// buildOutput generates a concatenated string of file contents based on the provided
+// options, reading paths out of fsys. Files are read and classified concurrently by a
+// pool of opts.Jobs workers, each opening its file exactly once; a collector
+// reassembles their results in the original slice order so the output stays
+// deterministic regardless of which worker finishes first.
+func buildOutput(paths []string, fsys fs.FS, opts Options) (output string, fileCount int, totalTokens int64) {
+	extMap := parseExtMap(opts.Extensions)
+
+	var grep *grepState
+	if opts.GrepPattern != "" {
+		re, err := regexp.Compile(opts.GrepPattern)
+		if err != nil {
+			log.Fatal("Invalid -grep pattern", "pattern", opts.GrepPattern, "error", err)
 		}
+		grep = &grepState{re: re, context: opts.GrepContext, maxResults: opts.MaxResults}
 	}
-	return files, nil
-}
 
-// buildOutput generates a concatenated string of file contents based on the provided options.
-func buildOutput(files []string, opts Options) (output string, fileCount int, totalTokens int64) {
-	var buffer bytes.Buffer
-	buffer.WriteString("Format description: The following are files in the Git repository" +
-		" of the project. The files are separated using {{File: filename.txt}}.\n\n")
-
-	var extMap map[string]struct{}
-	if opts.Extensions != "" {
-		extMap = make(map[string]struct{})
-		for _, ext := range strings.Split(opts.Extensions, ",") {
-			trimmed := strings.TrimSpace(ext)
-			if trimmed == "" {
-				extMap[""] = struct{}{}
-			} else {
-				extMap["."+trimmed] = struct{}{}
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	indexCh := make(chan int)
+	resultCh := make(chan fileResult, jobs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexCh {
+				resultCh <- processFile(index, paths[index], fsys, opts, extMap, grep)
 			}
-		}
+		}()
 	}
 
-	for _, file := range files {
-		if isHiddenFile(file) || !isTextFile(file) {
-			log.Info("Skipping file", "file", file)
-			continue
+	go func() {
+		for i := range paths {
+			indexCh <- i
 		}
-		totalTokens += estimateTokens(file)
-		fileExt := filepath.Ext(file)
-		if extMap != nil {
-			if _, ok := extMap[fileExt]; !ok {
-				log.Info("Skipping file with excluded extension", "file", file)
+		close(indexCh)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	var buffer bytes.Buffer
+	buffer.WriteString("Format description: The following are files from the selected" +
+		" source. The files are separated using {{File: filename.txt}}.\n\n")
+
+	// Results can arrive out of order; buffer them until they can be written in the
+	// original sequence. This also lets -max-results be enforced here, against the
+	// first N matches in path order, instead of racing workers against each other.
+	pending := make(map[int]fileResult)
+	next := 0
+	grepIncluded := 0
+	grepTruncated := 0
+	for result := range resultCh {
+		pending[result.index] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			totalTokens += r.tokens
+			if r.skip {
 				continue
 			}
-		}
-		content, err := os.ReadFile(file)
-		if err != nil {
-			log.Error("Failed to read file", "file", file, "error", err)
-			continue
-		}
-		buffer.WriteString(fmt.Sprintf("{{File: %s}}\n", file))
-		if opts.IncludeLineNumbers {
-			lines := strings.Split(string(content), "\n")
-			for i, line := range lines {
-				buffer.WriteString(fmt.Sprintf("%d: %s\n", i+1, line))
+			if r.grepMatched && grep.maxResults > 0 {
+				if grepIncluded >= grep.maxResults {
+					grepTruncated++
+					continue
+				}
+				grepIncluded++
 			}
-		} else {
-			buffer.Write(content)
+			buffer.Write(r.content)
+			fileCount++
 		}
-		buffer.WriteString("\n")
-		fileCount++
 	}
+
+	if grepTruncated > 0 {
+		log.Info("Additional files matched -grep but were truncated by -max-results", "count", grepTruncated)
+	}
+
 	return buffer.String(), fileCount, totalTokens
 }
 
+// parseExtMap turns a comma-separated extension list (as stored in Options.Extensions)
+// into a lookup set of dot-prefixed extensions, or nil if no filter was requested.
+func parseExtMap(extensions string) map[string]struct{} {
+	if extensions == "" {
+		return nil
+	}
+	extMap := make(map[string]struct{})
+	for _, ext := range strings.Split(extensions, ",") {
+		trimmed := strings.TrimSpace(ext)
+		if trimmed == "" {
+			extMap[""] = struct{}{}
+		} else {
+			extMap["."+trimmed] = struct{}{}
+		}
+	}
+	return extMap
+}
+
+// processFile reads, classifies, and renders a single file, opening it exactly once.
+// It returns a fileResult tagged with index so buildOutput's collector can restore the
+// original file order.
+func processFile(index int, path string, fsys fs.FS, opts Options, extMap map[string]struct{}, grep *grepState) fileResult {
+	if isHiddenFile(path) {
+		log.Info("Skipping file", "file", path)
+		return fileResult{index: index, skip: true}
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		log.Error("Failed to read file", "file", path, "error", err)
+		return fileResult{index: index, skip: true}
+	}
+
+	sampleLen := len(content)
+	if sampleLen > 512 {
+		sampleLen = 512
+	}
+	if !isTextSample(content[:sampleLen]) {
+		log.Info("Skipping file", "file", path)
+		return fileResult{index: index, skip: true}
+	}
+
+	tokens := int64(len(content)) * 10 / 35 // divide by 3.5 to estimate tokens for code
+
+	if extMap != nil {
+		if _, ok := extMap[filepath.Ext(path)]; !ok {
+			log.Info("Skipping file with excluded extension", "file", path)
+			return fileResult{index: index, skip: true, tokens: tokens}
+		}
+	}
+
+	if grep != nil {
+		lines, include, matched := grep.matchLines(content)
+		if !matched {
+			return fileResult{index: index, skip: true, tokens: tokens}
+		}
+		var buf bytes.Buffer
+		renderGrepMatch(&buf, path, lines, include, opts)
+		return fileResult{index: index, content: buf.Bytes(), tokens: tokens, grepMatched: true}
+	}
+
+	var buf bytes.Buffer
+	renderFile(&buf, path, content, opts)
+	return fileResult{index: index, content: buf.Bytes(), tokens: tokens}
+}
+
+// renderFile writes a single file's framing and contents into buffer, prefixing each
+// line with its line number when opts.IncludeLineNumbers is set.
+func renderFile(buffer *bytes.Buffer, name string, content []byte, opts Options) {
+	buffer.WriteString(fmt.Sprintf("{{File: %s}}\n", name))
+	if opts.IncludeLineNumbers {
+		lines := strings.Split(string(content), "\n")
+		for i, line := range lines {
+			buffer.WriteString(fmt.Sprintf("%d: %s\n", i+1, line))
+		}
+	} else {
+		buffer.Write(content)
+	}
+	buffer.WriteString("\n")
+}
+
 // writeOutput handles the output based on options.
 func writeOutput(output string, opts Options) error {
 	if opts.CopyToClipboard {
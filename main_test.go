@@ -1,7 +1,10 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -16,11 +19,13 @@ func TestIsTextFile(t *testing.T) {
 	defer os.Remove(textFile)
 	defer os.Remove(binaryFile)
 
-	if !isTextFile(textFile) {
+	fsys := os.DirFS(".")
+
+	if !isTextFile(fsys, textFile) {
 		t.Errorf("Expected %s to be identified as a text file.", textFile)
 	}
 
-	if isTextFile(binaryFile) {
+	if isTextFile(fsys, binaryFile) {
 		t.Errorf("Expected %s to be identified as a binary file.", binaryFile)
 	}
 }
@@ -51,8 +56,13 @@ func TestBuildOutput(t *testing.T) {
 
 	os.WriteFile(testFiles[0], []byte("Line 1\nLine 2\n"), 0644)
 	os.WriteFile(testFiles[1], []byte("Another file.\nLine 2."), 0644)
+	defer func() {
+		for _, f := range testFiles {
+			os.Remove(f)
+		}
+	}()
 
-	output, count, _ := buildOutput(testFiles, opts)
+	output, count, _ := buildOutput(testFiles, os.DirFS("."), opts)
 	if cnt := strings.Count(output, "{{File: "); cnt != 3 {
 		// one in the format description, one for each file
 		t.Errorf("Expected 3 file markers, got %d", cnt)
@@ -60,11 +70,40 @@ func TestBuildOutput(t *testing.T) {
 	if cnt := strings.Count(output, "\n"); cnt < 4 {
 		t.Errorf("Expected multiple lines in the output, got %d", cnt)
 	}
+	if count != 2 {
+		t.Errorf("Expected 2 files to be processed, got %d", count)
+	}
+}
+
+func TestBuildOutputOrderingWithMultipleJobs(t *testing.T) {
+	testFiles := []string{"a.txt", "b.txt", "c.txt", "d.txt"}
+	opts := Options{Jobs: 4}
+
+	for i, f := range testFiles {
+		os.WriteFile(f, []byte(strings.Repeat(string(rune('a'+i)), 10)), 0644)
+	}
+	defer func() {
+		for _, f := range testFiles {
+			os.Remove(f)
+		}
+	}()
+
+	output, count, _ := buildOutput(testFiles, os.DirFS("."), opts)
+	if count != len(testFiles) {
+		t.Fatalf("Expected %d files to be processed, got %d", len(testFiles), count)
+	}
 
+	var lastIndex int
 	for _, f := range testFiles {
-		os.Remove(f)
+		idx := strings.Index(output, "{{File: "+f+"}}")
+		if idx == -1 {
+			t.Fatalf("Expected output to contain marker for %s", f)
+		}
+		if idx < lastIndex {
+			t.Errorf("Expected files to appear in order, %s appeared before the previous file", f)
+		}
+		lastIndex = idx
 	}
-	_ = count
 }
 
 func TestWriteOutput(t *testing.T) {
@@ -87,6 +126,169 @@ func TestWriteOutput(t *testing.T) {
 
 	os.Remove(outputFile)
 }
+
+func TestArchiveSource(t *testing.T) {
+	archivePath := "test_archive.zip"
+
+	zipFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create test archive: %v", err)
+	}
+	zw := zip.NewWriter(zipFile)
+	for name, content := range map[string]string{
+		"hello.txt":  "Hello, world!",
+		"binary.bin": "\x00\xFF\x00\xFF",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s to test archive: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write %s to test archive: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close test archive writer: %v", err)
+	}
+	zipFile.Close()
+	defer os.Remove(archivePath)
+
+	paths, fsys, cleanup, err := (ArchiveSource{Path: archivePath}).List()
+	if err != nil {
+		t.Fatalf("ArchiveSource.List returned error: %v", err)
+	}
+	defer cleanup()
+
+	output, count, _ := buildOutput(paths, fsys, Options{})
+	if count != 1 {
+		t.Errorf("Expected 1 file to be included, got %d", count)
+	}
+	if !strings.Contains(output, "{{File: hello.txt}}") {
+		t.Errorf("Expected output to contain hello.txt, got %q", output)
+	}
+	if strings.Contains(output, "binary.bin") {
+		t.Errorf("Expected binary.bin to be excluded from output, got %q", output)
+	}
+}
+
+func TestArchiveSourceStripsDotSlashPrefix(t *testing.T) {
+	archivePath := "test_archive.tar"
+
+	tarFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create test archive: %v", err)
+	}
+	tw := tar.NewWriter(tarFile)
+	content := []byte("Hello, world!")
+	if err := tw.WriteHeader(&tar.Header{Name: "./hello.txt", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("Failed to write tar entry: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	tarFile.Close()
+	defer os.Remove(archivePath)
+
+	paths, fsys, cleanup, err := (ArchiveSource{Path: archivePath}).List()
+	if err != nil {
+		t.Fatalf("ArchiveSource.List returned error: %v", err)
+	}
+	defer cleanup()
+
+	output, count, _ := buildOutput(paths, fsys, Options{})
+	if count != 1 {
+		t.Errorf("Expected 1 file to be included, got %d", count)
+	}
+	if !strings.Contains(output, "{{File: hello.txt}}") {
+		t.Errorf("Expected a ./-prefixed tar entry to be normalized to hello.txt, got %q", output)
+	}
+}
+
+func TestDirSourceHonorsGitignore(t *testing.T) {
+	root := t.TempDir()
+
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("ignored.txt\n"), 0644)
+	os.WriteFile(filepath.Join(root, "kept.txt"), []byte("keep me"), 0644)
+	os.WriteFile(filepath.Join(root, "ignored.txt"), []byte("skip me"), 0644)
+
+	paths, _, cleanup, err := (DirSource{Root: root}).List()
+	if err != nil {
+		t.Fatalf("DirSource.List returned error: %v", err)
+	}
+	defer cleanup()
+
+	var foundKept, foundIgnored bool
+	for _, p := range paths {
+		switch p {
+		case "kept.txt":
+			foundKept = true
+		case "ignored.txt":
+			foundIgnored = true
+		}
+	}
+	if !foundKept {
+		t.Errorf("Expected kept.txt to be listed, got %v", paths)
+	}
+	if foundIgnored {
+		t.Errorf("Expected ignored.txt to be excluded, got %v", paths)
+	}
+}
+
+func TestBuildOutputGrepFiltering(t *testing.T) {
+	testFiles := []string{"match.txt", "nomatch.txt"}
+	os.WriteFile(testFiles[0], []byte("intro\nTODO: fix this\noutro"), 0644)
+	os.WriteFile(testFiles[1], []byte("nothing interesting here"), 0644)
+	defer func() {
+		for _, f := range testFiles {
+			os.Remove(f)
+		}
+	}()
+
+	opts := Options{GrepPattern: "TODO"}
+	output, count, _ := buildOutput(testFiles, os.DirFS("."), opts)
+	if count != 1 {
+		t.Fatalf("Expected 1 file to match, got %d", count)
+	}
+	if !strings.Contains(output, "{{File: match.txt}}") {
+		t.Errorf("Expected output to contain match.txt, got %q", output)
+	}
+	if strings.Contains(output, "nomatch.txt") {
+		t.Errorf("Expected nomatch.txt to be excluded, got %q", output)
+	}
+	if strings.Contains(output, "intro") || strings.Contains(output, "outro") {
+		t.Errorf("Expected only the matching line to be included, got %q", output)
+	}
+}
+
+func TestBuildOutputMaxResults(t *testing.T) {
+	testFiles := []string{"a.txt", "b.txt", "c.txt"}
+	for _, f := range testFiles {
+		os.WriteFile(f, []byte("TODO: fix this"), 0644)
+	}
+	defer func() {
+		for _, f := range testFiles {
+			os.Remove(f)
+		}
+	}()
+
+	// Jobs > 1 exercises the default parallel path: -max-results must still pick the
+	// first N matches in path order, not whichever workers happen to finish first.
+	opts := Options{GrepPattern: "TODO", MaxResults: 2, Jobs: 4}
+	output, count, _ := buildOutput(testFiles, os.DirFS("."), opts)
+	if count != 2 {
+		t.Fatalf("Expected -max-results to cap matches at 2, got %d", count)
+	}
+	if !strings.Contains(output, "{{File: a.txt}}") || !strings.Contains(output, "{{File: b.txt}}") {
+		t.Errorf("Expected the first two matches, a.txt and b.txt, to be included, got %q", output)
+	}
+	if strings.Contains(output, "{{File: c.txt}}") {
+		t.Errorf("Expected c.txt to be truncated by -max-results, got %q", output)
+	}
+}
+
 func TestEstimateTokens(t *testing.T) {
 	tests := []struct {
 		filename string
@@ -99,6 +301,7 @@ func TestEstimateTokens(t *testing.T) {
 		{"large.txt", []byte(strings.Repeat("a", 700)), 200},
 	}
 
+	fsys := os.DirFS(".")
 	for _, tt := range tests {
 		// Write the test file
 		err := os.WriteFile(tt.filename, tt.content, 0644)
@@ -110,7 +313,7 @@ func TestEstimateTokens(t *testing.T) {
 		defer os.Remove(tt.filename)
 
 		// Estimate tokens
-		result := estimateTokens(tt.filename)
+		result := estimateTokens(fsys, tt.filename)
 		if result != tt.expected {
 			t.Errorf("estimateTokens(%q) = %d, want %d", tt.filename, result, tt.expected)
 		}
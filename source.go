@@ -0,0 +1,338 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Source abstracts where Concatenatrix's input files come from, so buildOutput can
+// read through a single fs.FS regardless of whether the files live in a Git working
+// tree, a plain directory, an archive, or a remote repository.
+type Source interface {
+	// List returns the paths that should be considered for concatenation, the fs.FS
+	// those paths can be opened from, and a cleanup function to release any
+	// resources (such as a cloned repository) backing that fs.FS once it's no
+	// longer needed. cleanup is always non-nil and safe to call even on error.
+	List() ([]string, fs.FS, func(), error)
+}
+
+// noopCleanup is returned by sources with nothing to release.
+func noopCleanup() {}
+
+// GitSource lists files tracked by the Git working tree in the current directory.
+type GitSource struct{}
+
+// List implements Source.
+func (GitSource) List() ([]string, fs.FS, func(), error) {
+	files, err := getTrackedFiles()
+	if err != nil {
+		return nil, nil, noopCleanup, err
+	}
+	return files, os.DirFS("."), noopCleanup, nil
+}
+
+// getTrackedFiles retrieves the list of files currently tracked by Git.
+func getTrackedFiles() ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "--cached")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		file := scanner.Text()
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// DirSource walks a plain directory on disk, honoring a .gitignore found at its root.
+type DirSource struct {
+	Root string
+}
+
+// List implements Source.
+func (s DirSource) List() ([]string, fs.FS, func(), error) {
+	root := s.Root
+	if root == "" {
+		root = "."
+	}
+	ignore := loadGitignore(root)
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == ".git" || ignore.matches(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore.matches(rel) {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, noopCleanup, err
+	}
+	return files, os.DirFS(root), noopCleanup, nil
+}
+
+// gitignoreMatcher holds a minimal set of .gitignore-style patterns, matched against
+// slash-separated paths relative to the directory the .gitignore lives in.
+type gitignoreMatcher struct {
+	patterns []string
+}
+
+// loadGitignore reads the .gitignore file at the root of dir, if any.
+func loadGitignore(dir string) gitignoreMatcher {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return gitignoreMatcher{}
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.Trim(line, "/"))
+	}
+	return gitignoreMatcher{patterns: patterns}
+}
+
+// matches reports whether rel should be excluded, checking each pattern against both
+// the full relative path and the base name.
+func (m gitignoreMatcher) matches(rel string) bool {
+	base := filepath.Base(rel)
+	for _, pattern := range m.patterns {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveEntry represents a single file extracted from an archive source.
+type archiveEntry struct {
+	Name    string
+	Content []byte
+}
+
+// normalizeArchiveEntryName cleans an archive entry's path and strips a leading "./",
+// so entries from archives created the common way (e.g. `tar czf out.tgz .`), which are
+// stored as "./foo.go", aren't mistaken for dotfiles by isHiddenFile.
+func normalizeArchiveEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean(name), "./")
+}
+
+// ArchiveSource reads entries out of a zip/tar/tar.gz/tar.bz2 archive.
+type ArchiveSource struct {
+	Path string
+}
+
+// List implements Source.
+func (s ArchiveSource) List() ([]string, fs.FS, func(), error) {
+	entries, err := extractArchiveEntries(s.Path)
+	if err != nil {
+		return nil, nil, noopCleanup, err
+	}
+	fsys := make(memFS, len(entries))
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		fsys[entry.Name] = entry.Content
+		files = append(files, entry.Name)
+	}
+	return files, fsys, noopCleanup, nil
+}
+
+// extractArchiveEntries reads all regular file entries out of a zip/tar/tar.gz/tar.bz2
+// archive, dispatching on the extension of path.
+func extractArchiveEntries(path string) ([]archiveEntry, error) {
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		return extractZipEntries(path)
+	case strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz"):
+		return extractTarEntries(path, true, false)
+	case strings.HasSuffix(path, ".tar.bz2"):
+		return extractTarEntries(path, false, true)
+	case strings.HasSuffix(path, ".tar"):
+		return extractTarEntries(path, false, false)
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", path)
+	}
+}
+
+// extractZipEntries reads all regular files out of a zip archive.
+func extractZipEntries(path string) ([]archiveEntry, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []archiveEntry
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			log.Error("Failed to open archive entry", "entry", f.Name, "error", err)
+			continue
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			log.Error("Failed to read archive entry", "entry", f.Name, "error", err)
+			continue
+		}
+		entries = append(entries, archiveEntry{Name: normalizeArchiveEntryName(f.Name), Content: content})
+	}
+	return entries, nil
+}
+
+// extractTarEntries reads all regular files out of a tar archive, optionally wrapped
+// in gzip or bzip2 compression.
+func extractTarEntries(path string, gzipped, bzipped bool) ([]archiveEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else if bzipped {
+		r = bzip2.NewReader(file)
+	}
+
+	tr := tar.NewReader(r)
+	var entries []archiveEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			log.Error("Failed to read archive entry", "entry", hdr.Name, "error", err)
+			continue
+		}
+		entries = append(entries, archiveEntry{Name: normalizeArchiveEntryName(hdr.Name), Content: content})
+	}
+	return entries, nil
+}
+
+// HTTPGitSource shallow-clones a remote Git repository into a temporary directory and
+// walks it like a DirSource.
+type HTTPGitSource struct {
+	URL string
+}
+
+// List implements Source.
+func (s HTTPGitSource) List() ([]string, fs.FS, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "concatenatrix-clone-*")
+	if err != nil {
+		return nil, nil, noopCleanup, err
+	}
+	cmd := exec.Command("git", "clone", "--depth", "1", s.URL, tmpDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, nil, noopCleanup, fmt.Errorf("git clone failed: %w: %s", err, out)
+	}
+
+	cleanup := func() { os.RemoveAll(tmpDir) }
+	files, fsys, _, err := DirSource{Root: tmpDir}.List()
+	if err != nil {
+		cleanup()
+		return nil, nil, noopCleanup, err
+	}
+	return files, fsys, cleanup, nil
+}
+
+// memFS is a minimal in-memory fs.FS backed by a map of file contents, used to expose
+// archive entries through the same Source interface as the filesystem-backed sources.
+type memFS map[string][]byte
+
+// Open implements fs.FS.
+func (m memFS) Open(name string) (fs.File, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{name: name, Reader: bytes.NewReader(content), size: int64(len(content))}, nil
+}
+
+// memFile is the fs.File returned by memFS.Open.
+type memFile struct {
+	name string
+	*bytes.Reader
+	size int64
+}
+
+// Stat implements fs.File.
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return memFileInfo{name: f.name, size: f.size}, nil
+}
+
+// Close implements fs.File.
+func (f *memFile) Close() error { return nil }
+
+// memFileInfo is the fs.FileInfo returned by memFile.Stat.
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (i memFileInfo) Name() string       { return filepath.Base(i.name) }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return 0 }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
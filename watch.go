@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// watchConfig is the optional .concatenatrix.yaml configuration read by -watch mode.
+type watchConfig struct {
+	Patterns []string `yaml:"patterns"`
+	Delay    string   `yaml:"delay"`
+	OnChange string   `yaml:"on_change"`
+}
+
+// loadWatchConfig reads .concatenatrix.yaml from the current directory, if present.
+func loadWatchConfig() watchConfig {
+	data, err := os.ReadFile(".concatenatrix.yaml")
+	if err != nil {
+		return watchConfig{}
+	}
+	var cfg watchConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		log.Error("Failed to parse .concatenatrix.yaml", "error", err)
+		return watchConfig{}
+	}
+	return cfg
+}
+
+// debounceDelay returns the configured debounce delay, defaulting to 300ms.
+func (c watchConfig) debounceDelay() time.Duration {
+	if c.Delay == "" {
+		return 300 * time.Millisecond
+	}
+	d, err := time.ParseDuration(c.Delay)
+	if err != nil {
+		log.Error("Invalid watch delay, using default", "delay", c.Delay, "error", err)
+		return 300 * time.Millisecond
+	}
+	return d
+}
+
+// matchesAnyPattern reports whether path matches one of the configured watch
+// patterns. A leading "**/" in a pattern matches at any directory depth.
+func matchesAnyPattern(path string, patterns []string) bool {
+	path = filepath.ToSlash(path)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if suffix, isDoubleStar := strings.CutPrefix(pattern, "**/"); isDoubleStar {
+			if ok, _ := filepath.Match(suffix, filepath.Base(path)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// watch rebuilds and rewrites the output whenever a file under root changes,
+// coalescing bursts of changes within the debounce window configured by
+// .concatenatrix.yaml. root is the on-disk directory the paths returned by
+// source.List are relative to. It blocks until the watcher is closed or hits a fatal
+// error.
+func watch(source Source, opts Options, root string) error {
+	cfg := loadWatchConfig()
+
+	paths, _, cleanup, err := source.List()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watchedDirs := make(map[string]struct{})
+	for _, path := range paths {
+		dir := filepath.Join(root, filepath.Dir(path))
+		if _, ok := watchedDirs[dir]; ok {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			log.Error("Failed to watch directory", "dir", dir, "error", err)
+			continue
+		}
+		watchedDirs[dir] = struct{}{}
+	}
+
+	log.Info("Watching for changes", "files", len(paths))
+
+	// Resolve the output file's absolute path up front, so writeOutput's own writes
+	// to it (when it lives inside a watched directory) don't trigger a rebuild loop.
+	var outputPath string
+	if opts.OutputFilename != "" {
+		if abs, err := filepath.Abs(opts.OutputFilename); err == nil {
+			outputPath = abs
+		}
+	}
+
+	delay := cfg.debounceDelay()
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if outputPath != "" {
+				if abs, err := filepath.Abs(event.Name); err == nil && abs == outputPath {
+					continue
+				}
+			}
+
+			// Watch newly created directories too, so files added under them are
+			// picked up without having to restart.
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.Add(event.Name); err != nil {
+						log.Error("Failed to watch new directory", "dir", event.Name, "error", err)
+					}
+					continue
+				}
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			if len(cfg.Patterns) > 0 {
+				rel, err := filepath.Rel(root, event.Name)
+				if err != nil {
+					rel = event.Name
+				}
+				if !matchesAnyPattern(rel, cfg.Patterns) {
+					continue
+				}
+			}
+
+			if timer == nil {
+				timer = time.AfterFunc(delay, func() { rebuild(source, opts, cfg) })
+			} else {
+				timer.Reset(delay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error("Watch error", "error", err)
+		}
+	}
+}
+
+// rebuild regenerates the output for source and, if writeOutput succeeds, runs the
+// configured on_change command.
+func rebuild(source Source, opts Options, cfg watchConfig) {
+	paths, fsys, cleanup, err := source.List()
+	if err != nil {
+		log.Error("Failed to list source files", "error", err)
+		return
+	}
+	defer cleanup()
+
+	output, fileCount, totalTokens := buildOutput(paths, fsys, opts)
+	if err := writeOutput(output, opts); err != nil {
+		log.Error("Error writing output", "error", err)
+		return
+	}
+	log.Info("Rebuilt output", "count", fileCount, "tokens", totalTokens)
+
+	if cfg.OnChange == "" {
+		return
+	}
+	cmd := exec.Command("sh", "-c", cfg.OnChange)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Error("on_change command failed", "error", err, "output", string(out))
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchRebuildsOnFileChange(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file.txt")
+	outputPath := filepath.Join(dir, "output.txt")
+
+	if err := os.WriteFile(filePath, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	source := DirSource{Root: dir}
+	opts := Options{OutputFilename: outputPath, Jobs: 1}
+
+	done := make(chan error, 1)
+	go func() { done <- watch(source, opts, dir) }()
+
+	// Give the watcher time to start before triggering a change.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filePath, []byte("v2"), 0644); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := os.ReadFile(outputPath)
+		if err == nil && strings.Contains(string(data), "v2") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for watch to rebuild the output")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}